@@ -15,7 +15,10 @@
 package placeholderdump
 
 import (
+	"strconv"
+
 	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig"
 	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
 	"github.com/caddyserver/caddy/v2/caddyconfig/httpcaddyfile"
 	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
@@ -23,7 +26,7 @@ import (
 
 // Initialize the module by registering it with Caddy
 func init() {
-	caddy.RegisterModule(PlaceholderDump{})
+	caddy.RegisterModule(new(PlaceholderDump))
 	httpcaddyfile.RegisterHandlerDirective("placeholder_dump", parseCaddyfile)
 }
 
@@ -52,6 +55,53 @@ func (m *PlaceholderDump) UnmarshalCaddyfile(d *caddyfile.Dispenser) error {
 					return d.ArgErr()
 				}
 				m.FilePermissions = d.Val()
+			case "roll_size_mb":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				val, err := strconv.Atoi(d.Val())
+				if err != nil {
+					return d.Errf("invalid roll_size_mb value: %v", err)
+				}
+				m.RollSizeMB = val
+			case "roll_keep":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				val, err := strconv.Atoi(d.Val())
+				if err != nil {
+					return d.Errf("invalid roll_keep value: %v", err)
+				}
+				m.RollKeep = val
+			case "roll_keep_days":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				val, err := strconv.Atoi(d.Val())
+				if err != nil {
+					return d.Errf("invalid roll_keep_days value: %v", err)
+				}
+				m.RollKeepDays = val
+			case "roll_compress":
+				if d.NextArg() {
+					val, err := strconv.ParseBool(d.Val())
+					if err != nil {
+						return d.Errf("invalid roll_compress value: %v", err)
+					}
+					m.RollCompress = val
+				} else {
+					m.RollCompress = true
+				}
+			case "roll_local_time":
+				if d.NextArg() {
+					val, err := strconv.ParseBool(d.Val())
+					if err != nil {
+						return d.Errf("invalid roll_local_time value: %v", err)
+					}
+					m.RollLocalTime = val
+				} else {
+					m.RollLocalTime = true
+				}
 			case "logger_suffix":
 				if !d.NextArg() {
 					return d.ArgErr()
@@ -62,6 +112,116 @@ func (m *PlaceholderDump) UnmarshalCaddyfile(d *caddyfile.Dispenser) error {
 					return d.ArgErr()
 				}
 				m.Content = d.Val()
+			case "buffer":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				val, err := strconv.Atoi(d.Val())
+				if err != nil {
+					return d.Errf("invalid buffer value: %v", err)
+				}
+				m.Buffer = val
+			case "flush_bytes":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				val, err := strconv.Atoi(d.Val())
+				if err != nil {
+					return d.Errf("invalid flush_bytes value: %v", err)
+				}
+				m.FlushBytes = val
+			case "flush_interval":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				dur, err := caddy.ParseDuration(d.Val())
+				if err != nil {
+					return d.Errf("invalid flush_interval value: %v", err)
+				}
+				m.FlushInterval = caddy.Duration(dur)
+			case "overflow":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				switch d.Val() {
+				case "block", "drop", "drop_oldest":
+					m.Overflow = d.Val()
+				default:
+					return d.Errf("unknown overflow policy: %s", d.Val())
+				}
+			case "encoder":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				m.Encoder = d.Val()
+			case "fields":
+				for nesting := d.Nesting(); d.NextBlock(nesting); {
+					field := &Field{Name: d.Val()}
+					if !d.NextArg() {
+						return d.ArgErr()
+					}
+					field.Placeholder = d.Val()
+					if d.NextArg() {
+						return d.ArgErr()
+					}
+					for fieldNesting := d.Nesting(); d.NextBlock(fieldNesting); {
+						if d.Val() != "filter" {
+							return d.Errf("unknown field option: %s", d.Val())
+						}
+						if !d.NextArg() {
+							return d.ArgErr()
+						}
+						filter := &FieldFilter{Name: d.Val(), Args: d.RemainingArgs()}
+						field.Filters = append(field.Filters, filter)
+					}
+					m.Fields = append(m.Fields, field)
+				}
+			case "sample":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				m.Sample = d.Val()
+			case "sample_key":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				m.SampleKey = d.Val()
+			case "rate_limit":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				m.RateLimit = d.Val()
+			case "writer":
+				if !d.NextArg() {
+					return d.ArgErr()
+				}
+				writerModuleName := d.Val()
+
+				// stdout, stderr, and discard live in the caddy package
+				// itself (since they're the default writers) and don't
+				// implement caddyfile.Unmarshaler, so they can't go through
+				// the usual UnmarshalModule flow; special-case them the
+				// same way Caddy's own "output" log directive does.
+				var wo caddy.WriterOpener
+				switch writerModuleName {
+				case "stdout":
+					wo = caddy.StdoutWriter{}
+				case "stderr":
+					wo = caddy.StderrWriter{}
+				case "discard":
+					wo = caddy.DiscardWriter{}
+				default:
+					unm, err := caddyfile.UnmarshalModule(d, "caddy.logging.writers."+writerModuleName)
+					if err != nil {
+						return err
+					}
+					var ok bool
+					wo, ok = unm.(caddy.WriterOpener)
+					if !ok {
+						return d.Errf("module %s (%T) is not a caddy.WriterOpener", writerModuleName, unm)
+					}
+				}
+				m.WriterRaw = caddyconfig.JSONModuleObject(wo, "output", writerModuleName, nil)
 			default:
 				return d.Errf("unknown option: %s", d.Val())
 			}
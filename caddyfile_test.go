@@ -0,0 +1,272 @@
+// Copyright 2025 Steffen Busch
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placeholderdump
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+)
+
+// newTestContext returns a caddy.Context suitable for Provision in tests;
+// the context is cancelled automatically when the test finishes.
+func newTestContext(t *testing.T) caddy.Context {
+	t.Helper()
+	ctx, cancel := caddy.NewContext(caddy.Context{Context: context.Background()})
+	t.Cleanup(cancel)
+	return ctx
+}
+
+// serveTestRequest drives m's full ServeHTTP path with repl available on the
+// request context, the same way Caddy wires it up at runtime.
+func serveTestRequest(t *testing.T, m *PlaceholderDump, repl *caddy.Replacer) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), caddy.ReplacerCtxKey, repl))
+	next := caddyhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error { return nil })
+	if err := m.ServeHTTP(httptest.NewRecorder(), req, next); err != nil {
+		t.Fatalf("ServeHTTP: %v", err)
+	}
+}
+
+// TestUnmarshalCaddyfileFieldsEndToEnd drives the full path for the "fields"
+// directive: parsing the Caddyfile block, provisioning, serving a request,
+// and reading back the structured, filtered output that landed on disk.
+func TestUnmarshalCaddyfileFieldsEndToEnd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.jsonl")
+
+	d := caddyfile.NewTestDispenser(`placeholder_dump {
+		file ` + path + `
+		encoder json
+		fields {
+			client_ip {test.ip} {
+				filter ip_mask ipv4=24
+			}
+			token {test.token} {
+				filter hash
+			}
+		}
+	}`)
+
+	m := new(PlaceholderDump)
+	if err := m.UnmarshalCaddyfile(d); err != nil {
+		t.Fatalf("UnmarshalCaddyfile: %v", err)
+	}
+	if err := m.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if err := m.Provision(newTestContext(t)); err != nil {
+		t.Fatalf("Provision: %v", err)
+	}
+
+	repl := caddy.NewReplacer()
+	repl.Set("test.ip", "203.0.113.42")
+	repl.Set("test.token", "secret")
+	serveTestRequest(t, m, repl)
+
+	if err := m.Cleanup(); err != nil {
+		t.Fatalf("Cleanup: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading dump file: %v", err)
+	}
+	want := `{"client_ip":"203.0.113.0","token":"` + hashHex("secret", 0) + `"}` + "\n"
+	if string(data) != want {
+		t.Fatalf("expected %q, got %q", want, string(data))
+	}
+}
+
+// TestUnmarshalCaddyfileWriterAndRollEndToEnd drives the async batching
+// pipeline end-to-end for both sink shapes it supports: a static file path
+// batched through the rotating lumberjack logger alongside a second writer
+// module sink, and a dynamic (per-request) file path that bypasses the
+// pipeline entirely and falls back to the inline write path.
+func TestUnmarshalCaddyfileWriterAndRollEndToEnd(t *testing.T) {
+	t.Run("static file with roll_* and a writer module are both batched through the pipeline", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "dump.log")
+
+		d := caddyfile.NewTestDispenser(`placeholder_dump {
+			file ` + path + `
+			roll_size_mb 10
+			roll_keep 3
+			writer discard
+			content {test.msg}
+		}`)
+
+		m := new(PlaceholderDump)
+		if err := m.UnmarshalCaddyfile(d); err != nil {
+			t.Fatalf("UnmarshalCaddyfile: %v", err)
+		}
+		if err := m.Validate(); err != nil {
+			t.Fatalf("Validate: %v", err)
+		}
+		if err := m.Provision(newTestContext(t)); err != nil {
+			t.Fatalf("Provision: %v", err)
+		}
+		if m.entries == nil {
+			t.Fatal("expected the async pipeline to be started for a static file + writer sink")
+		}
+
+		repl := caddy.NewReplacer()
+		repl.Set("test.msg", "hello from the pipeline")
+		serveTestRequest(t, m, repl)
+
+		if err := m.Cleanup(); err != nil {
+			t.Fatalf("Cleanup: %v", err)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("reading dump file: %v", err)
+		}
+		if want := "hello from the pipeline\n"; string(data) != want {
+			t.Fatalf("expected %q, got %q", want, string(data))
+		}
+	})
+
+	t.Run("dynamic file path falls back to an inline per-request write", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "dump.log")
+
+		d := caddyfile.NewTestDispenser(`placeholder_dump {
+			file {test.path}
+			content {test.msg}
+		}`)
+
+		m := new(PlaceholderDump)
+		if err := m.UnmarshalCaddyfile(d); err != nil {
+			t.Fatalf("UnmarshalCaddyfile: %v", err)
+		}
+		if err := m.Validate(); err != nil {
+			t.Fatalf("Validate: %v", err)
+		}
+		if err := m.Provision(newTestContext(t)); err != nil {
+			t.Fatalf("Provision: %v", err)
+		}
+		if m.entries != nil {
+			t.Fatal("expected no async pipeline for a dynamic (per-request) file path")
+		}
+
+		repl := caddy.NewReplacer()
+		repl.Set("test.path", path)
+		repl.Set("test.msg", "hello inline")
+		serveTestRequest(t, m, repl)
+
+		if err := m.Cleanup(); err != nil {
+			t.Fatalf("Cleanup: %v", err)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("reading dump file: %v", err)
+		}
+		if want := "hello inline\n"; string(data) != want {
+			t.Fatalf("expected %q, got %q", want, string(data))
+		}
+	})
+}
+
+// TestUnmarshalCaddyfileSamplingAndRateLimitEndToEnd drives requests through
+// the full ServeHTTP path to check that "sample" and "rate_limit" actually
+// gate which requests reach the sink, not just that shouldSample/allowRate
+// return the right bool in isolation.
+func TestUnmarshalCaddyfileSamplingAndRateLimitEndToEnd(t *testing.T) {
+	t.Run("sample 1/N dumps only every Nth request", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "dump.log")
+
+		d := caddyfile.NewTestDispenser(`placeholder_dump {
+			file ` + path + `
+			sample 1/2
+			content {test.msg}
+		}`)
+
+		m := new(PlaceholderDump)
+		if err := m.UnmarshalCaddyfile(d); err != nil {
+			t.Fatalf("UnmarshalCaddyfile: %v", err)
+		}
+		if err := m.Validate(); err != nil {
+			t.Fatalf("Validate: %v", err)
+		}
+		if err := m.Provision(newTestContext(t)); err != nil {
+			t.Fatalf("Provision: %v", err)
+		}
+
+		repl := caddy.NewReplacer()
+		for i := 0; i < 4; i++ {
+			repl.Set("test.msg", "entry")
+			serveTestRequest(t, m, repl)
+		}
+
+		if err := m.Cleanup(); err != nil {
+			t.Fatalf("Cleanup: %v", err)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("reading dump file: %v", err)
+		}
+		if got := strings.Count(string(data), "entry\n"); got != 2 {
+			t.Fatalf("expected 2 of 4 requests to be dumped under sample 1/2, got %d", got)
+		}
+	})
+
+	t.Run("rate_limit caps dumped requests once its bucket is exhausted", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "dump.log")
+
+		d := caddyfile.NewTestDispenser(`placeholder_dump {
+			file ` + path + `
+			rate_limit 2/1h
+			content {test.msg}
+		}`)
+
+		m := new(PlaceholderDump)
+		if err := m.UnmarshalCaddyfile(d); err != nil {
+			t.Fatalf("UnmarshalCaddyfile: %v", err)
+		}
+		if err := m.Validate(); err != nil {
+			t.Fatalf("Validate: %v", err)
+		}
+		if err := m.Provision(newTestContext(t)); err != nil {
+			t.Fatalf("Provision: %v", err)
+		}
+
+		repl := caddy.NewReplacer()
+		for i := 0; i < 4; i++ {
+			repl.Set("test.msg", "entry")
+			serveTestRequest(t, m, repl)
+		}
+
+		if err := m.Cleanup(); err != nil {
+			t.Fatalf("Cleanup: %v", err)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("reading dump file: %v", err)
+		}
+		if got := strings.Count(string(data), "entry\n"); got != 2 {
+			t.Fatalf("expected only 2 of 4 requests to be dumped once rate_limit's bucket of 2 is exhausted, got %d", got)
+		}
+	})
+}
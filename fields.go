@@ -0,0 +1,107 @@
+// Copyright 2025 Steffen Busch
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placeholderdump
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/caddyserver/caddy/v2"
+)
+
+// Field is a single named value extracted from a placeholder, used in
+// structured output mode instead of the free-form Content template.
+type Field struct {
+	// Name is the key the field is emitted under.
+	Name string `json:"name"`
+
+	// Placeholder is the placeholder expression resolved to produce the
+	// field's raw value, e.g. "{http.request.remote.host}".
+	Placeholder string `json:"placeholder"`
+
+	// Filters are applied to the resolved value, in order, before encoding.
+	Filters []*FieldFilter `json:"filters,omitempty"`
+}
+
+// encodeFields resolves every configured field, applies its filters, and
+// encodes the result according to m.Encoder ("json" or "logfmt").
+func (m *PlaceholderDump) encodeFields(repl *caddy.Replacer) (string, error) {
+	names := make([]string, 0, len(m.Fields))
+	values := make(map[string]string, len(m.Fields))
+
+	for _, field := range m.Fields {
+		val := repl.ReplaceAll(field.Placeholder, "")
+		for _, filter := range field.Filters {
+			var err error
+			val, err = applyFieldFilter(val, filter)
+			if err != nil {
+				return "", fmt.Errorf("field %q: filter %q: %v", field.Name, filter.Name, err)
+			}
+		}
+		names = append(names, field.Name)
+		values[field.Name] = val
+	}
+
+	if m.Encoder == "logfmt" {
+		return encodeLogfmt(names, values), nil
+	}
+	return encodeJSONObject(names, values)
+}
+
+// encodeJSONObject marshals the named values as a single-line JSON object,
+// preserving the declaration order of the fields.
+func encodeJSONObject(names []string, values map[string]string) (string, error) {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return "", err
+		}
+		val, err := json.Marshal(values[name])
+		if err != nil {
+			return "", err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(val)
+	}
+	b.WriteByte('}')
+	return b.String(), nil
+}
+
+// encodeLogfmt renders the named values as logfmt key=value pairs,
+// preserving the declaration order of the fields.
+func encodeLogfmt(names []string, values map[string]string) string {
+	var b strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(name)
+		b.WriteByte('=')
+		v := values[name]
+		if v == "" || strings.ContainsAny(v, " =\"") {
+			b.WriteString(fmt.Sprintf("%q", v))
+		} else {
+			b.WriteString(v)
+		}
+	}
+	return b.String()
+}
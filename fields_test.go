@@ -0,0 +1,162 @@
+// Copyright 2025 Steffen Busch
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placeholderdump
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2"
+)
+
+func TestEncodeJSONObject(t *testing.T) {
+	tests := []struct {
+		name   string
+		names  []string
+		values map[string]string
+		want   string
+	}{
+		{
+			name:   "preserves declaration order",
+			names:  []string{"b", "a"},
+			values: map[string]string{"a": "1", "b": "2"},
+			want:   `{"b":"2","a":"1"}`,
+		},
+		{
+			name:   "escapes special characters",
+			names:  []string{"msg"},
+			values: map[string]string{"msg": `say "hi"` + "\n"},
+			want:   `{"msg":"say \"hi\"\n"}`,
+		},
+		{
+			name:   "empty fields encode as an empty object",
+			names:  nil,
+			values: map[string]string{},
+			want:   `{}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := encodeJSONObject(tt.names, tt.values)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEncodeLogfmt(t *testing.T) {
+	tests := []struct {
+		name   string
+		names  []string
+		values map[string]string
+		want   string
+	}{
+		{
+			name:   "preserves declaration order",
+			names:  []string{"b", "a"},
+			values: map[string]string{"a": "1", "b": "2"},
+			want:   `b=2 a=1`,
+		},
+		{
+			name:   "quotes values containing spaces",
+			names:  []string{"msg"},
+			values: map[string]string{"msg": "hello world"},
+			want:   `msg="hello world"`,
+		},
+		{
+			name:   "quotes empty values",
+			names:  []string{"msg"},
+			values: map[string]string{"msg": ""},
+			want:   `msg=""`,
+		},
+		{
+			name:   "bare values are not quoted",
+			names:  []string{"status"},
+			values: map[string]string{"status": "ok"},
+			want:   `status=ok`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := encodeLogfmt(tt.names, tt.values)
+			if got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEncodeFieldsAppliesFilters(t *testing.T) {
+	m := &PlaceholderDump{
+		Encoder: "json",
+		Fields: []*Field{
+			{
+				Name:        "token",
+				Placeholder: "{test.token}",
+				Filters: []*FieldFilter{
+					{Name: "hash"},
+				},
+			},
+		},
+	}
+	for _, field := range m.Fields {
+		for _, filter := range field.Filters {
+			if err := validateFieldFilter(filter); err != nil {
+				t.Fatalf("unexpected error validating filter: %v", err)
+			}
+		}
+	}
+
+	repl := caddy.NewReplacer()
+	repl.Set("test.token", "secret")
+	got, err := m.encodeFields(repl)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `{"token":"` + hashHex("secret", 0) + `"}`; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestEncodeFieldsFilterErrorIsWrapped(t *testing.T) {
+	m := &PlaceholderDump{
+		Encoder: "json",
+		Fields: []*Field{
+			{
+				Name:        "client_ip",
+				Placeholder: "{test.ip}",
+				Filters: []*FieldFilter{
+					{Name: "ip_mask", Args: []string{"ipv4=abc"}},
+				},
+			},
+		},
+	}
+
+	repl := caddy.NewReplacer()
+	repl.Set("test.ip", "203.0.113.42")
+	_, err := m.encodeFields(repl)
+	if err == nil {
+		t.Fatal("expected an error from the invalid ip_mask filter")
+	}
+	if want := `field "client_ip": filter "ip_mask"`; !strings.Contains(err.Error(), want) {
+		t.Fatalf("expected error to be wrapped with %q, got %q", want, err.Error())
+	}
+}
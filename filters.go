@@ -0,0 +1,282 @@
+// Copyright 2025 Steffen Busch
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placeholderdump
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FieldFilter is a redaction or transformation step applied to a field's
+// resolved value before encoding, modeled on Caddy's filter log encoder:
+// ip_mask, cookie, query, regexp, and hash.
+type FieldFilter struct {
+	// Name selects the filter: ip_mask, cookie, query, regexp, or hash.
+	Name string `json:"name"`
+
+	// Args holds the filter's parameters; their meaning depends on Name.
+	Args []string `json:"args,omitempty"`
+
+	// re is the compiled pattern for the regexp filter, set in Provision so
+	// ServeHTTP never compiles a pattern on the hot path.
+	re *regexp.Regexp
+}
+
+// applyFieldFilter runs a single filter against a resolved field value.
+func applyFieldFilter(value string, f *FieldFilter) (string, error) {
+	switch f.Name {
+	case "ip_mask":
+		return filterIPMask(value, f.Args)
+	case "cookie":
+		return filterCookie(value, f.Args)
+	case "query":
+		return filterQuery(value, f.Args)
+	case "regexp":
+		return filterRegexp(value, f)
+	case "hash":
+		return filterHash(value, f.Args)
+	default:
+		return "", fmt.Errorf("unknown filter: %s", f.Name)
+	}
+}
+
+// filterIPMask zeroes the low bits of a parsed IP address. Args are
+// "ipv4=<bits>" and/or "ipv6=<bits>"; unspecified families are left
+// unmasked. Values that don't parse as an IP are passed through unchanged.
+func filterIPMask(value string, args []string) (string, error) {
+	v4Bits, v6Bits, err := parseIPMaskArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	ip := net.ParseIP(strings.TrimSpace(value))
+	if ip == nil {
+		return value, nil
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.Mask(net.CIDRMask(v4Bits, 32)).String(), nil
+	}
+	return ip.Mask(net.CIDRMask(v6Bits, 128)).String(), nil
+}
+
+// parseIPMaskArgs parses the ip_mask filter's "ipv4=<bits>"/"ipv6=<bits>"
+// arguments, defaulting unspecified families to an unmasked width.
+func parseIPMaskArgs(args []string) (v4Bits, v6Bits int, err error) {
+	v4Bits, v6Bits = 32, 128
+	for _, arg := range args {
+		family, bitsStr, ok := strings.Cut(arg, "=")
+		if !ok {
+			return 0, 0, fmt.Errorf("invalid ip_mask argument: %s", arg)
+		}
+		bits, err := strconv.Atoi(bitsStr)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid ip_mask bits in %q: %v", arg, err)
+		}
+		switch family {
+		case "ipv4":
+			if bits < 0 || bits > 32 {
+				return 0, 0, fmt.Errorf("ip_mask ipv4 bits must be between 0 and 32, got %d", bits)
+			}
+			v4Bits = bits
+		case "ipv6":
+			if bits < 0 || bits > 128 {
+				return 0, 0, fmt.Errorf("ip_mask ipv6 bits must be between 0 and 128, got %d", bits)
+			}
+			v6Bits = bits
+		default:
+			return 0, 0, fmt.Errorf("unknown ip_mask family: %s", family)
+		}
+	}
+	return v4Bits, v6Bits, nil
+}
+
+// filterCookie rewrites a named cookie within a resolved Cookie header
+// value, reserializing it with the stdlib's cookie-list semantics rather
+// than a bare split, so quoted values aren't mistaken for a "; "-delimited
+// boundary. This only understands the Cookie-header shape (a
+// "name=value; name2=value2" list); it deliberately does not attempt to
+// auto-detect a Set-Cookie header (one cookie plus attributes like Path,
+// Domain, Expires) the way Caddy's own cookie filter doesn't either, since
+// an attribute can collide with a real cookie's name and a heuristic split
+// between the two would silently leave a matching cookie unredacted. Args
+// are "<name> replace <value>", "<name> hash", or "<name> delete".
+func filterCookie(value string, args []string) (string, error) {
+	if len(args) < 2 {
+		return "", fmt.Errorf("cookie filter requires a name and a mode")
+	}
+	name, mode := args[0], args[1]
+
+	var replacement string
+	switch mode {
+	case "delete", "hash":
+	case "replace":
+		if len(args) < 3 {
+			return "", fmt.Errorf("cookie filter replace mode requires a value")
+		}
+		replacement = args[2]
+	default:
+		return "", fmt.Errorf("unknown cookie filter mode: %s", mode)
+	}
+
+	req := &http.Request{Header: http.Header{"Cookie": {value}}}
+	kept := make([]string, 0, len(args))
+	for _, c := range req.Cookies() {
+		if c.Name != name {
+			kept = append(kept, c.String())
+			continue
+		}
+		if mode == "delete" {
+			continue
+		}
+		if mode == "hash" {
+			c.Value = hashHex(c.Value, 0)
+		} else {
+			c.Value = replacement
+		}
+		kept = append(kept, c.String())
+	}
+	return strings.Join(kept, "; "), nil
+}
+
+// filterQuery rewrites a named parameter within a resolved URL query string
+// and reserializes it. Args are "<param> replace <value>", "<param> hash",
+// or "<param> delete".
+func filterQuery(value string, args []string) (string, error) {
+	if len(args) < 2 {
+		return "", fmt.Errorf("query filter requires a parameter name and a mode")
+	}
+	param, mode := args[0], args[1]
+
+	q, err := url.ParseQuery(value)
+	if err != nil {
+		return "", fmt.Errorf("parsing query: %v", err)
+	}
+	switch mode {
+	case "delete":
+		q.Del(param)
+	case "hash":
+		if v := q.Get(param); v != "" {
+			q.Set(param, hashHex(v, 0))
+		}
+	case "replace":
+		if len(args) < 3 {
+			return "", fmt.Errorf("query filter replace mode requires a value")
+		}
+		if q.Has(param) {
+			q.Set(param, args[2])
+		}
+	default:
+		return "", fmt.Errorf("unknown query filter mode: %s", mode)
+	}
+	return q.Encode(), nil
+}
+
+// filterRegexp replaces matches of the filter's compiled pattern with its
+// replacement template. Args are "<pattern> <replacement>".
+func filterRegexp(value string, f *FieldFilter) (string, error) {
+	if f.re == nil {
+		return "", fmt.Errorf("regexp filter was not compiled")
+	}
+	if len(f.Args) < 2 {
+		return "", fmt.Errorf("regexp filter requires a pattern and a replacement")
+	}
+	return f.re.ReplaceAllString(value, f.Args[1]), nil
+}
+
+// filterHash replaces the value with its SHA-256 hex digest, optionally
+// truncated to the length given as the first arg.
+func filterHash(value string, args []string) (string, error) {
+	truncate := 0
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			return "", fmt.Errorf("invalid hash truncation length: %v", err)
+		}
+		truncate = n
+	}
+	return hashHex(value, truncate), nil
+}
+
+// hashHex returns the hex-encoded SHA-256 digest of value, truncated to
+// truncate characters if truncate is positive and shorter than the digest.
+func hashHex(value string, truncate int) string {
+	sum := sha256.Sum256([]byte(value))
+	digest := hex.EncodeToString(sum[:])
+	if truncate > 0 && truncate < len(digest) {
+		return digest[:truncate]
+	}
+	return digest
+}
+
+// validateFieldFilter checks a filter's argument shape once, at Provision
+// time, so a Caddyfile typo fails config load instead of being discovered
+// (and logged) on the first live request, and every one after it. For the
+// regexp filter, this also compiles the pattern.
+func validateFieldFilter(f *FieldFilter) error {
+	switch f.Name {
+	case "ip_mask":
+		_, _, err := parseIPMaskArgs(f.Args)
+		return err
+	case "cookie":
+		return validateNamedModeArgs("cookie", f.Args)
+	case "query":
+		return validateNamedModeArgs("query", f.Args)
+	case "regexp":
+		if len(f.Args) < 2 {
+			return fmt.Errorf("regexp filter requires a pattern and a replacement")
+		}
+		re, err := regexp.Compile(f.Args[0])
+		if err != nil {
+			return fmt.Errorf("compiling regexp filter: %v", err)
+		}
+		f.re = re
+		return nil
+	case "hash":
+		if len(f.Args) == 0 {
+			return nil
+		}
+		if _, err := strconv.Atoi(f.Args[0]); err != nil {
+			return fmt.Errorf("invalid hash truncation length: %v", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown filter: %s", f.Name)
+	}
+}
+
+// validateNamedModeArgs checks the "<name> <mode> [value]" argument shape
+// shared by the cookie and query filters.
+func validateNamedModeArgs(filterName string, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("%s filter requires a name and a mode", filterName)
+	}
+	switch args[1] {
+	case "delete", "hash":
+	case "replace":
+		if len(args) < 3 {
+			return fmt.Errorf("%s filter replace mode requires a value", filterName)
+		}
+	default:
+		return fmt.Errorf("unknown %s filter mode: %s", filterName, args[1])
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+// Copyright 2025 Steffen Busch
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placeholderdump
+
+import "testing"
+
+func TestFilterIPMask(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{name: "ipv4 default bits leave address unmasked", value: "203.0.113.42", args: nil, want: "203.0.113.42"},
+		{name: "ipv4 masked to /24", value: "203.0.113.42", args: []string{"ipv4=24"}, want: "203.0.113.0"},
+		{name: "ipv6 masked to /64", value: "2001:db8::1234", args: []string{"ipv6=64"}, want: "2001:db8::"},
+		{name: "non-IP value passes through unchanged", value: "not-an-ip", args: []string{"ipv4=24"}, want: "not-an-ip"},
+		{name: "unknown family errors", value: "203.0.113.42", args: []string{"ipv9=24"}, wantErr: true},
+		{name: "non-numeric bits errors", value: "203.0.113.42", args: []string{"ipv4=abc"}, wantErr: true},
+		{name: "out-of-range ipv4 bits errors", value: "203.0.113.42", args: []string{"ipv4=99"}, wantErr: true},
+		{name: "negative ipv4 bits errors", value: "203.0.113.42", args: []string{"ipv4=-1"}, wantErr: true},
+		{name: "out-of-range ipv6 bits errors", value: "2001:db8::1234", args: []string{"ipv6=200"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := filterIPMask(tt.value, tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFilterCookie(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "delete removes the named cookie",
+			value: "session=abc123; theme=dark",
+			args:  []string{"session", "delete"},
+			want:  "theme=dark",
+		},
+		{
+			name:  "replace substitutes a fixed value",
+			value: "session=abc123; theme=dark",
+			args:  []string{"session", "replace", "REDACTED"},
+			want:  "session=REDACTED; theme=dark",
+		},
+		{
+			name:  "hash replaces the value with its digest",
+			value: "session=abc123",
+			args:  []string{"session", "hash"},
+			want:  "session=" + hashHex("abc123", 0),
+		},
+		{
+			name:  "unrelated cookies pass through unchanged",
+			value: "theme=dark",
+			args:  []string{"session", "delete"},
+			want:  "theme=dark",
+		},
+		{
+			name:  "quoted value is reserialized with quotes intact",
+			value: `session="a b"; theme=dark`,
+			args:  []string{"theme", "replace", "light"},
+			want:  `session="a b"; theme=light`,
+		},
+		{
+			name:  "a cookie named after a Set-Cookie attribute keyword is still matched and redacted",
+			value: "session=abc123; Domain=sensitive-internal-value",
+			args:  []string{"Domain", "hash"},
+			want:  "session=abc123; Domain=" + hashHex("sensitive-internal-value", 0),
+		},
+		{
+			name:  "a cookie named after a Set-Cookie attribute keyword is still matched and deleted",
+			value: "a=1; Expires=2",
+			args:  []string{"Expires", "delete"},
+			want:  "a=1",
+		},
+		{name: "missing mode errors", value: "session=abc123", args: []string{"session"}, wantErr: true},
+		{name: "replace without a value errors", value: "session=abc123", args: []string{"session", "replace"}, wantErr: true},
+		{name: "unknown mode errors", value: "session=abc123", args: []string{"session", "bogus"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := filterCookie(tt.value, tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFilterQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{name: "delete removes the named parameter", value: "token=secret&id=1", args: []string{"token", "delete"}, want: "id=1"},
+		{name: "replace substitutes a fixed value", value: "token=secret", args: []string{"token", "replace", "REDACTED"}, want: "token=REDACTED"},
+		{name: "hash replaces the value with its digest", value: "token=secret", args: []string{"token", "hash"}, want: "token=" + hashHex("secret", 0)},
+		{name: "missing parameter is a no-op", value: "id=1", args: []string{"token", "delete"}, want: "id=1"},
+		{name: "missing mode errors", value: "token=secret", args: []string{"token"}, wantErr: true},
+		{name: "replace without a value errors", value: "token=secret", args: []string{"token", "replace"}, wantErr: true},
+		{name: "unknown mode errors", value: "token=secret", args: []string{"token", "bogus"}, wantErr: true},
+		{name: "unparsable query errors", value: "%zz", args: []string{"token", "delete"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := filterQuery(tt.value, tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFilterRegexp(t *testing.T) {
+	f := &FieldFilter{Name: "regexp", Args: []string{`\d+`, "#"}}
+	if err := validateFieldFilter(f); err != nil {
+		t.Fatalf("unexpected error compiling filter: %v", err)
+	}
+
+	got, err := filterRegexp("order-12345", f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "order-#"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	if _, err := filterRegexp("order-12345", &FieldFilter{Name: "regexp", Args: []string{`\d+`, "#"}}); err == nil {
+		t.Fatal("expected an error when the filter was never compiled")
+	}
+}
+
+func TestFilterHash(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{name: "no truncation returns the full digest", value: "secret", args: nil, want: hashHex("secret", 0)},
+		{name: "truncates to the given length", value: "secret", args: []string{"8"}, want: hashHex("secret", 0)[:8]},
+		{name: "non-numeric truncation length errors", value: "secret", args: []string{"abc"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := filterHash(tt.value, tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValidateFieldFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  *FieldFilter
+		wantErr bool
+	}{
+		{name: "ip_mask with valid bits", filter: &FieldFilter{Name: "ip_mask", Args: []string{"ipv4=24"}}},
+		{name: "ip_mask with malformed bits errors", filter: &FieldFilter{Name: "ip_mask", Args: []string{"ipv4=abc"}}, wantErr: true},
+		{name: "ip_mask with out-of-range bits errors", filter: &FieldFilter{Name: "ip_mask", Args: []string{"ipv4=99"}}, wantErr: true},
+		{name: "cookie delete", filter: &FieldFilter{Name: "cookie", Args: []string{"session", "delete"}}},
+		{name: "cookie missing mode errors", filter: &FieldFilter{Name: "cookie", Args: []string{"session"}}, wantErr: true},
+		{name: "query replace", filter: &FieldFilter{Name: "query", Args: []string{"token", "replace", "REDACTED"}}},
+		{name: "query replace without value errors", filter: &FieldFilter{Name: "query", Args: []string{"token", "replace"}}, wantErr: true},
+		{name: "regexp with pattern and replacement", filter: &FieldFilter{Name: "regexp", Args: []string{`\d+`, "#"}}},
+		{name: "regexp missing replacement errors", filter: &FieldFilter{Name: "regexp", Args: []string{`\d+`}}, wantErr: true},
+		{name: "regexp with invalid pattern errors", filter: &FieldFilter{Name: "regexp", Args: []string{`(`, "#"}}, wantErr: true},
+		{name: "hash with no args", filter: &FieldFilter{Name: "hash", Args: nil}},
+		{name: "hash with numeric truncation", filter: &FieldFilter{Name: "hash", Args: []string{"8"}}},
+		{name: "hash with non-numeric truncation errors", filter: &FieldFilter{Name: "hash", Args: []string{"abc"}}, wantErr: true},
+		{name: "unknown filter errors", filter: &FieldFilter{Name: "bogus"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateFieldFilter(tt.filter)
+			if tt.wantErr && err == nil {
+				t.Fatal("expected an error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.wantErr && tt.filter.Name == "regexp" && tt.filter.re == nil {
+				t.Fatal("expected validateFieldFilter to compile the regexp pattern")
+			}
+		})
+	}
+}
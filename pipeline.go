@@ -0,0 +1,176 @@
+// Copyright 2025 Steffen Busch
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placeholderdump
+
+import (
+	"bufio"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// defaultBufferSize is the channel capacity used when Buffer is unset.
+const defaultBufferSize = 1024
+
+// defaultFlushInterval is used when neither FlushBytes nor FlushInterval is
+// configured, so a low-traffic route still gets writes on disk within a
+// bounded time instead of waiting indefinitely for bufio's internal buffer
+// to fill.
+const defaultFlushInterval = time.Second
+
+// combinedSink fans a batch write out to the module's stable sinks (the
+// rotating file logger and/or the configured writer module), logging a
+// failure on one sink without skipping the other.
+type combinedSink struct {
+	m *PlaceholderDump
+}
+
+// Write implements io.Writer for combinedSink.
+func (s combinedSink) Write(p []byte) (int, error) {
+	if s.m.fileLogger != nil {
+		if _, err := s.m.fileLogger.Write(p); err != nil {
+			s.m.logger.Error("Failed to write batch to file", zap.Error(err))
+		}
+	}
+	if s.m.writer != nil {
+		if _, err := s.m.writer.Write(p); err != nil {
+			s.m.logger.Error("Failed to write batch to writer", zap.Error(err))
+		}
+	}
+	return len(p), nil
+}
+
+// startPipeline starts the background goroutine that drains m.entries and
+// flushes batched writes to the stable sinks (fileLogger and/or writer).
+// It's only worth running when at least one such sink is configured; the
+// dynamic per-request file path has no stable target to batch against and
+// keeps writing inline.
+func (m *PlaceholderDump) startPipeline() {
+	bufferSize := m.Buffer
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+	m.entries = make(chan string, bufferSize)
+	m.pipelineDone = make(chan struct{})
+
+	go m.runPipeline()
+}
+
+// runPipeline is the body of the background batching goroutine.
+func (m *PlaceholderDump) runPipeline() {
+	defer close(m.pipelineDone)
+
+	bw := bufio.NewWriter(combinedSink{m})
+	pending := 0
+
+	flushInterval := time.Duration(m.FlushInterval)
+	if flushInterval == 0 && m.FlushBytes == 0 {
+		flushInterval = defaultFlushInterval
+	}
+
+	var tick <-chan time.Time
+	if flushInterval > 0 {
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	flush := func() {
+		if err := bw.Flush(); err != nil {
+			m.logger.Error("Failed to flush batched writes", zap.Error(err))
+		}
+		pending = 0
+	}
+
+	for {
+		select {
+		case entry, ok := <-m.entries:
+			if !ok {
+				flush()
+				return
+			}
+			bw.WriteString(entry)
+			bw.WriteByte('\n')
+			pending += len(entry) + 1
+			if m.FlushBytes > 0 && pending >= m.FlushBytes {
+				flush()
+			}
+		case <-tick:
+			flush()
+		}
+	}
+}
+
+// enqueue hands a resolved entry to the background pipeline, honoring the
+// configured Overflow policy when the buffer is full. It holds pipelineMu's
+// read lock for the duration of the send so stopPipeline can't close entries
+// out from under it; once pipelineClosed is set, the entry is silently
+// dropped instead of sent, since the pipeline is shutting down.
+func (m *PlaceholderDump) enqueue(entry string) {
+	m.pipelineMu.RLock()
+	defer m.pipelineMu.RUnlock()
+	if m.pipelineClosed {
+		return
+	}
+
+	if m.Overflow == "" || m.Overflow == "block" {
+		m.entries <- entry
+		return
+	}
+
+	select {
+	case m.entries <- entry:
+		return
+	default:
+	}
+
+	switch m.Overflow {
+	case "drop_oldest":
+		select {
+		case <-m.entries:
+			m.recordDrop()
+		default:
+		}
+		select {
+		case m.entries <- entry:
+		default:
+			m.recordDrop()
+		}
+	default: // "drop"
+		m.recordDrop()
+	}
+}
+
+// recordDrop increments the dropped-entry count and logs it.
+func (m *PlaceholderDump) recordDrop() {
+	total := m.droppedCount.Add(1)
+	m.logger.Warn("Dropped dump entry; pipeline buffer is full", zap.Int64("dropped_total", total))
+}
+
+// stopPipeline closes the entries channel and waits for the background
+// goroutine to flush and exit. It takes pipelineMu's write lock before
+// closing, which only succeeds once every in-flight enqueue call has
+// returned, so closing entries here can never race with a concurrent
+// ServeHTTP still sending on it.
+func (m *PlaceholderDump) stopPipeline() {
+	if m.entries == nil {
+		return
+	}
+	m.pipelineMu.Lock()
+	m.pipelineClosed = true
+	close(m.entries)
+	m.pipelineMu.Unlock()
+	<-m.pipelineDone
+}
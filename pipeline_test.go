@@ -0,0 +1,111 @@
+// Copyright 2025 Steffen Busch
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placeholderdump
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestEnqueueOverflowDrop(t *testing.T) {
+	m := &PlaceholderDump{
+		Overflow: "drop",
+		logger:   zap.NewNop(),
+		entries:  make(chan string, 1),
+	}
+	m.enqueue("first")
+	m.enqueue("second")
+
+	if got := <-m.entries; got != "first" {
+		t.Fatalf("expected the first entry to be kept, got %q", got)
+	}
+	if dropped := m.droppedCount.Load(); dropped != 1 {
+		t.Fatalf("expected 1 dropped entry, got %d", dropped)
+	}
+}
+
+func TestEnqueueOverflowDropOldest(t *testing.T) {
+	m := &PlaceholderDump{
+		Overflow: "drop_oldest",
+		logger:   zap.NewNop(),
+		entries:  make(chan string, 1),
+	}
+	m.enqueue("first")
+	m.enqueue("second")
+
+	if got := <-m.entries; got != "second" {
+		t.Fatalf("expected the newest entry to replace the oldest, got %q", got)
+	}
+	if dropped := m.droppedCount.Load(); dropped != 1 {
+		t.Fatalf("expected drop_oldest to count the evicted entry as a drop, got %d", dropped)
+	}
+}
+
+func TestStopPipelineDoesNotRaceWithEnqueue(t *testing.T) {
+	m := &PlaceholderDump{logger: zap.NewNop()}
+	m.startPipeline()
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					m.enqueue("entry")
+				}
+			}
+		}()
+	}
+
+	// Give the senders a moment to start racing with stopPipeline, then
+	// shut the pipeline down while they're still running; a close-after-send
+	// race would panic the whole test binary instead of just failing it.
+	time.Sleep(time.Millisecond)
+	m.stopPipeline()
+	close(stop)
+	wg.Wait()
+}
+
+func TestEnqueueOverflowBlock(t *testing.T) {
+	m := &PlaceholderDump{entries: make(chan string, 1)}
+	m.enqueue("first")
+
+	done := make(chan struct{})
+	go func() {
+		m.enqueue("second")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("expected enqueue to block while the buffer is full")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	<-m.entries
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected enqueue to unblock once the buffer has room")
+	}
+}
@@ -15,15 +15,21 @@
 package placeholderdump
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/caddyserver/caddy/v2"
 	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
 	"go.uber.org/zap"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 // PlaceholderDump is a Caddy module that dumps a placeholder to a file or logs it to a specified logger.
@@ -35,19 +41,157 @@ type PlaceholderDump struct {
 	// in the file path that will be resolved at runtime.
 	File string `json:"file,omitempty"`
 
+	// FilePermissions is the Unix file mode (e.g. "0644") used when creating
+	// File. Defaults to 0644. Only applies when File contains per-request
+	// placeholders: the persistent rotating file opened for a static File
+	// path is managed by lumberjack, which always creates files with its
+	// own fixed mode and has no permission option, so FilePermissions would
+	// silently have no effect there. Provision rejects that combination.
+	FilePermissions string `json:"file_permissions,omitempty"`
+
+	// RollSizeMB is the maximum size in megabytes of the file before it gets
+	// rotated. Maps to lumberjack's MaxSize. Only applies when File contains
+	// no per-request placeholders, since rotation requires a stable path.
+	RollSizeMB int `json:"roll_size_mb,omitempty"`
+
+	// RollKeep is the maximum number of old rotated files to retain. Maps to
+	// lumberjack's MaxBackups.
+	RollKeep int `json:"roll_keep,omitempty"`
+
+	// RollKeepDays is the maximum number of days to retain old rotated files.
+	// Maps to lumberjack's MaxAge.
+	RollKeepDays int `json:"roll_keep_days,omitempty"`
+
+	// RollCompress enables gzip compression of rotated files. Maps to
+	// lumberjack's Compress.
+	RollCompress bool `json:"roll_compress,omitempty"`
+
+	// RollLocalTime uses the local timezone for rotated file timestamps
+	// instead of UTC. Maps to lumberjack's LocalTime.
+	RollLocalTime bool `json:"roll_local_time,omitempty"`
+
 	// LoggerSuffix is the suffix appended to the module's logger name.
 	// If set, the content will be logged to the logger with this suffix.
 	LoggerSuffix string `json:"logger_suffix,omitempty"`
 
 	// Content is the content to be written to the file or logged.
 	// It can contain placeholders that will be resolved at runtime.
+	// Not used when Encoder is "json" or "logfmt"; set Fields instead.
 	Content string `json:"content,omitempty"`
 
+	// Fields declares named fields, each bound to a placeholder expression,
+	// for structured output instead of the free-form Content template.
+	// Requires Encoder to be "json" or "logfmt".
+	Fields []*Field `json:"fields,omitempty"`
+
+	// Encoder selects how a request is rendered: "raw" (default) writes the
+	// resolved Content string as-is; "json" and "logfmt" instead encode
+	// Fields.
+	Encoder string `json:"encoder,omitempty"`
+
+	// WriterRaw is a raw Caddy module configuration for a caddy.WriterOpener,
+	// allowing any writer module (file, net, stdout, stderr, discard, ...) to be
+	// used as the sink for resolved content, the same way Caddy's own logging
+	// subsystem lets you pick a writer for log output.
+	WriterRaw json.RawMessage `json:"writer,omitempty" caddy:"namespace=caddy.logging.writers inline_key=output"`
+
+	// Buffer is the capacity of the channel buffering resolved entries
+	// between ServeHTTP and the background writer goroutine. Defaults to
+	// 1024. Only applies when File (static) and/or WriterRaw is set.
+	Buffer int `json:"buffer,omitempty"`
+
+	// FlushBytes flushes the pending batch once it reaches this many bytes.
+	// Zero disables size-based flushing.
+	FlushBytes int `json:"flush_bytes,omitempty"`
+
+	// FlushInterval flushes the pending batch on this cadence regardless of
+	// its size. Zero falls back to defaultFlushInterval unless FlushBytes is
+	// set, so a low-traffic route still gets bounded write latency by default.
+	FlushInterval caddy.Duration `json:"flush_interval,omitempty"`
+
+	// Overflow selects what happens when the buffer is full: "block" (the
+	// default) waits for room, "drop" discards the new entry, and
+	// "drop_oldest" discards the oldest buffered entry to make room.
+	Overflow string `json:"overflow,omitempty"`
+
+	// Sample gates whether a request is dumped at all: either a fraction
+	// ("0.01", checked against a fast RNG) or a deterministic "1/N" ratio
+	// (an atomic counter modulo N). Empty means dump every request.
+	Sample string `json:"sample,omitempty"`
+
+	// SampleKey, if set, makes sampling deterministic per resolved value
+	// instead of per request: a placeholder is resolved and hashed with
+	// FNV-1a, and the hash modulo the Sample ratio's N decides sampling.
+	// Requires Sample to be a "1/N" ratio.
+	SampleKey string `json:"sample_key,omitempty"`
+
+	// RateLimit caps dumps to at most N per duration, e.g. "100/1s", using
+	// a per-instance token bucket. Empty disables rate limiting.
+	RateLimit string `json:"rate_limit,omitempty"`
+
 	// logger provides structured logging for the module.
 	// It's initialized in the Provision method and used throughout the module for debug information.
 	logger *zap.Logger
 
-	// mutex ensures thread-safe writes to the file for this instance.
+	// writer is the opened sink obtained from WriterRaw, kept open for the
+	// lifetime of the module and closed in Cleanup.
+	writer io.WriteCloser
+
+	// fileLogger is a persistent, rotating writer for File, used when File
+	// contains no per-request placeholders. It is created once in Provision
+	// and closed in Cleanup, avoiding an open/close syscall per request.
+	fileLogger *lumberjack.Logger
+
+	// filePermissions is the parsed form of FilePermissions, resolved once
+	// in Provision so the write path never parses it per request.
+	filePermissions os.FileMode
+
+	// entries buffers resolved entries between ServeHTTP and the background
+	// writer goroutine, when a stable sink (fileLogger and/or writer) is
+	// configured. Nil otherwise, in which case writes happen inline.
+	entries chan string
+
+	// pipelineDone is closed once the background writer goroutine has
+	// flushed and exited, signaling Cleanup that it's safe to close the
+	// underlying sinks.
+	pipelineDone chan struct{}
+
+	// pipelineMu guards pipelineClosed and serializes it against enqueue, so
+	// stopPipeline never closes entries while a concurrent ServeHTTP is
+	// still sending on it. enqueue holds a read lock for the duration of its
+	// send (allowing concurrent requests to enqueue together); stopPipeline
+	// takes the write lock, which only succeeds once every in-flight enqueue
+	// has returned, and closes entries while still holding it.
+	pipelineMu sync.RWMutex
+
+	// pipelineClosed is set under pipelineMu's write lock right before
+	// entries is closed; enqueue checks it under the read lock and skips the
+	// send once set, instead of sending on a channel stopPipeline may have
+	// already closed.
+	pipelineClosed bool
+
+	// droppedCount tracks entries discarded under the "drop" and
+	// "drop_oldest" overflow policies.
+	droppedCount atomic.Int64
+
+	// sampleMode, sampleDenom, sampleFraction, sampleRNG, and sampleCounter
+	// hold the parsed form of Sample and the state needed to evaluate it;
+	// see provisionSampling.
+	sampleMode     int
+	sampleDenom    uint64
+	sampleFraction float64
+	sampleRNG      *xorshift64
+	sampleCounter  atomic.Uint64
+
+	// rateLimitCapacity, rateLimitInterval, rateTokens, and rateLastRefill
+	// implement the token bucket described by RateLimit; see
+	// provisionRateLimit and allowRate.
+	rateLimitCapacity int64
+	rateLimitInterval time.Duration
+	rateTokens        atomic.Int64
+	rateLastRefill    atomic.Int64
+
+	// mutex ensures thread-safe inline writes to a dynamic (per-request) file path for this instance.
 	// However, if the file is shared across multiple instances, there is a risk of
 	// concurrent writes leading to data corruption.
 	// But this module is intended that each use of the module has its own file.
@@ -55,7 +199,7 @@ type PlaceholderDump struct {
 }
 
 // CaddyModule returns the Caddy module information.
-func (PlaceholderDump) CaddyModule() caddy.ModuleInfo {
+func (*PlaceholderDump) CaddyModule() caddy.ModuleInfo {
 	return caddy.ModuleInfo{
 		ID:  "http.handlers.placeholder_dump",
 		New: func() caddy.Module { return new(PlaceholderDump) },
@@ -70,18 +214,126 @@ func (m *PlaceholderDump) Provision(ctx caddy.Context) error {
 	if m.mutex == nil {
 		m.mutex = &sync.Mutex{}
 	}
+
+	// If a writer module is configured, load it and open it once for the
+	// lifetime of this module instance.
+	if m.WriterRaw != nil {
+		mod, err := ctx.LoadModule(m, "WriterRaw")
+		if err != nil {
+			return fmt.Errorf("loading writer module: %v", err)
+		}
+		wo, ok := mod.(caddy.WriterOpener)
+		if !ok {
+			return fmt.Errorf("module %T is not a caddy.WriterOpener", mod)
+		}
+		writer, err := wo.OpenWriter()
+		if err != nil {
+			return fmt.Errorf("opening writer: %v", err)
+		}
+		m.writer = writer
+	}
+
+	// Resolve FilePermissions once, so the write path never parses it per
+	// request. Defaults to 0644, matching the prior hard-coded mode.
+	m.filePermissions = 0644
+	if m.FilePermissions != "" {
+		perm, err := strconv.ParseUint(m.FilePermissions, 8, 32)
+		if err != nil {
+			return fmt.Errorf("invalid file_permissions: %v", err)
+		}
+		m.filePermissions = os.FileMode(perm)
+	}
+
+	// If File is set and contains no per-request placeholders, the path is
+	// stable for the lifetime of the module, so open a persistent, rotating
+	// writer for it instead of reopening the file on every request.
+	if m.File != "" && !strings.Contains(m.File, "{") {
+		if m.FilePermissions != "" {
+			return fmt.Errorf("file_permissions has no effect on the persistent rotating file opened for a static file path (%q): lumberjack always creates files with its own fixed mode, not FilePermissions; remove file_permissions, or use a file path with per-request placeholders", m.File)
+		}
+		m.fileLogger = &lumberjack.Logger{
+			Filename:   m.File,
+			MaxSize:    m.RollSizeMB,
+			MaxBackups: m.RollKeep,
+			MaxAge:     m.RollKeepDays,
+			Compress:   m.RollCompress,
+			LocalTime:  m.RollLocalTime,
+		}
+	}
+
+	// Validate each field's filter arguments once, so a Caddyfile typo
+	// (malformed ip_mask bits, a missing cookie/query mode, a non-numeric
+	// hash truncation length, or a bad regexp) fails config load instead of
+	// logging an error on every subsequent request. This also compiles the
+	// regexp filter's pattern, so ServeHTTP never compiles it on the hot path.
+	for _, field := range m.Fields {
+		for _, filter := range field.Filters {
+			if err := validateFieldFilter(filter); err != nil {
+				return fmt.Errorf("field %q: filter %q: %v", field.Name, filter.Name, err)
+			}
+		}
+	}
+
+	// A stable sink can be batched: start the background writer goroutine
+	// so ServeHTTP only has to format and enqueue.
+	if m.fileLogger != nil || m.writer != nil {
+		m.startPipeline()
+	}
+
+	if err := m.provisionSampling(); err != nil {
+		return err
+	}
+	if err := m.provisionRateLimit(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Cleanup stops the background writer goroutine, then closes the writer and
+// file logger opened during Provision, if any.
+func (m *PlaceholderDump) Cleanup() error {
+	m.stopPipeline()
+
+	if m.writer != nil {
+		if err := m.writer.Close(); err != nil {
+			return err
+		}
+	}
+	if m.fileLogger != nil {
+		return m.fileLogger.Close()
+	}
 	return nil
 }
 
 // Validate ensures the configuration is correct.
 func (m *PlaceholderDump) Validate() error {
-	// Ensure at least one of File or LoggerSuffix is set.
-	if m.File == "" && m.LoggerSuffix == "" {
-		return fmt.Errorf("either file or logger_suffix must be set")
+	// Ensure at least one of File, LoggerSuffix, or WriterRaw is set.
+	if m.File == "" && m.LoggerSuffix == "" && m.WriterRaw == nil {
+		return fmt.Errorf("either file, logger_suffix, or writer must be set")
+	}
+
+	switch m.Encoder {
+	case "", "raw":
+		if len(m.Fields) > 0 {
+			return fmt.Errorf("fields requires encoder to be \"json\" or \"logfmt\"")
+		}
+		if m.Content == "" {
+			return fmt.Errorf("content must be set")
+		}
+	case "json", "logfmt":
+		if len(m.Fields) == 0 {
+			return fmt.Errorf("fields must be set when encoder is %q", m.Encoder)
+		}
+	default:
+		return fmt.Errorf("unknown encoder: %s", m.Encoder)
 	}
-	if m.Content == "" {
-		return fmt.Errorf("content must be set")
+
+	switch m.Overflow {
+	case "", "block", "drop", "drop_oldest":
+	default:
+		return fmt.Errorf("unknown overflow policy: %s", m.Overflow)
 	}
+
 	return nil
 }
 
@@ -93,9 +345,28 @@ func (m *PlaceholderDump) ServeHTTP(w http.ResponseWriter, r *http.Request, next
 		return caddyhttp.Error(http.StatusInternalServerError, nil)
 	}
 
-	// Resolve placeholders in the content.
-	resolvedContent := repl.ReplaceAll(m.Content, "")
-	resolvedContent = strings.TrimSpace(resolvedContent)
+	// Gate on rate limiting and sampling before resolving anything, so the
+	// common case of skipping a request stays cheap.
+	if !m.allowRate() {
+		return next.ServeHTTP(w, r)
+	}
+	if !m.shouldSample(repl) {
+		return next.ServeHTTP(w, r)
+	}
+
+	// Resolve the entry to write, either from the Content template or, in
+	// structured output mode, by encoding Fields.
+	var resolvedContent string
+	if m.Encoder == "json" || m.Encoder == "logfmt" {
+		encoded, err := m.encodeFields(repl)
+		if err != nil {
+			m.logger.Error("Failed to encode fields", zap.Error(err))
+			return next.ServeHTTP(w, r)
+		}
+		resolvedContent = encoded
+	} else {
+		resolvedContent = strings.TrimSpace(repl.ReplaceAll(m.Content, ""))
+	}
 
 	// Skip processing if the resolved content is empty.
 	if resolvedContent == "" {
@@ -108,27 +379,33 @@ func (m *PlaceholderDump) ServeHTTP(w http.ResponseWriter, r *http.Request, next
 		m.logger.Named(m.LoggerSuffix).Info("Logging resolved content", zap.String("content", resolvedContent))
 	}
 
-	// If File is set, after resolving placeholders, write the content to the file.
-	resolvedFile := repl.ReplaceAll(m.File, "")
-	if resolvedFile != "" {
-		// Lock the instance-specific mutex to ensure thread-safe file writes.
-		m.mutex.Lock()
-		defer m.mutex.Unlock()
+	// A stable sink (the rotating file logger and/or the writer module) is
+	// written to asynchronously: ServeHTTP only formats and enqueues, and
+	// the background pipeline goroutine batches the actual writes.
+	if m.entries != nil {
+		m.enqueue(resolvedContent)
+	}
 
-		// Open the file for appending, creating it if it doesn't exist.
-		const filePermissions = 0644
-		f, err := os.OpenFile(resolvedFile, os.O_APPEND|os.O_WRONLY|os.O_CREATE, filePermissions)
-		if err != nil {
-			m.logger.Error("Failed to open file", zap.String("file", resolvedFile), zap.Error(err))
-			return next.ServeHTTP(w, r)
-		}
-		defer f.Close()
+	// A dynamic (per-request) file path has no stable target to batch
+	// against, so it's still opened and written inline, under the mutex.
+	if m.fileLogger == nil {
+		resolvedFile := repl.ReplaceAll(m.File, "")
+		if resolvedFile != "" {
+			m.mutex.Lock()
+
+			f, err := os.OpenFile(resolvedFile, os.O_APPEND|os.O_WRONLY|os.O_CREATE, m.filePermissions)
+			if err != nil {
+				m.logger.Error("Failed to open file", zap.String("file", resolvedFile), zap.Error(err))
+			} else {
+				if _, err := f.WriteString(resolvedContent + "\n"); err != nil {
+					m.logger.Error("Failed to write to file", zap.Error(err))
+				} else {
+					m.logger.Debug("Wrote content to file", zap.String("file", resolvedFile), zap.String("content", resolvedContent))
+				}
+				f.Close()
+			}
 
-		// Write the resolved content to the file.
-		if _, err := f.WriteString(resolvedContent + "\n"); err != nil {
-			m.logger.Error("Failed to write to file", zap.Error(err))
-		} else {
-			m.logger.Debug("Wrote content to file", zap.String("file", resolvedFile), zap.String("content", resolvedContent))
+			m.mutex.Unlock()
 		}
 	}
 
@@ -140,5 +417,6 @@ var (
 	_ caddy.Module                = (*PlaceholderDump)(nil)
 	_ caddy.Provisioner           = (*PlaceholderDump)(nil)
 	_ caddy.Validator             = (*PlaceholderDump)(nil)
+	_ caddy.CleanerUpper          = (*PlaceholderDump)(nil)
 	_ caddyhttp.MiddlewareHandler = (*PlaceholderDump)(nil)
 )
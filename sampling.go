@@ -0,0 +1,139 @@
+// Copyright 2025 Steffen Busch
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placeholderdump
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+)
+
+// Sampling modes for the parsed Sample option.
+const (
+	sampleModeNone = iota
+	sampleModeRatio
+	sampleModeFraction
+)
+
+// provisionSampling parses Sample into one of the two supported forms: a
+// deterministic "1/N" ratio, or a fractional rate checked against a fast RNG.
+func (m *PlaceholderDump) provisionSampling() error {
+	if m.Sample == "" {
+		if m.SampleKey != "" {
+			return fmt.Errorf("sample_key requires sample to be set to a 1/N ratio")
+		}
+		return nil
+	}
+
+	if numerator, denominator, ok := strings.Cut(m.Sample, "/"); ok {
+		if numerator != "1" {
+			return fmt.Errorf("sample ratio must be of the form 1/N, got %q", m.Sample)
+		}
+		denom, err := strconv.ParseUint(denominator, 10, 64)
+		if err != nil || denom == 0 {
+			return fmt.Errorf("invalid sample ratio denominator: %q", denominator)
+		}
+		m.sampleMode = sampleModeRatio
+		m.sampleDenom = denom
+		return nil
+	}
+
+	if m.SampleKey != "" {
+		return fmt.Errorf("sample_key requires sample to be set to a 1/N ratio")
+	}
+
+	fraction, err := strconv.ParseFloat(m.Sample, 64)
+	if err != nil || fraction <= 0 || fraction > 1 {
+		return fmt.Errorf("invalid sample rate: %q", m.Sample)
+	}
+	m.sampleMode = sampleModeFraction
+	m.sampleFraction = fraction
+	m.sampleRNG = newXorshift64(uint64(time.Now().UnixNano()) | 1)
+	return nil
+}
+
+// shouldSample reports whether the current request should be dumped,
+// resolving SampleKey through repl when stable, hash-based sampling is
+// configured.
+func (m *PlaceholderDump) shouldSample(repl *caddy.Replacer) bool {
+	switch m.sampleMode {
+	case sampleModeRatio:
+		if m.SampleKey != "" {
+			key := repl.ReplaceAll(m.SampleKey, "")
+			h := fnv.New64a()
+			h.Write([]byte(key))
+			return h.Sum64()%m.sampleDenom == 0
+		}
+		return m.sampleCounter.Add(1)%m.sampleDenom == 0
+	case sampleModeFraction:
+		return m.sampleRNG.lockedFloat64() < m.sampleFraction
+	default:
+		return true
+	}
+}
+
+// provisionRateLimit parses RateLimit ("<N>/<duration>") into a token
+// bucket's capacity and refill interval.
+func (m *PlaceholderDump) provisionRateLimit() error {
+	if m.RateLimit == "" {
+		return nil
+	}
+	countStr, durationStr, ok := strings.Cut(m.RateLimit, "/")
+	if !ok {
+		return fmt.Errorf("rate_limit must be of the form <N>/<duration>, got %q", m.RateLimit)
+	}
+	count, err := strconv.ParseInt(countStr, 10, 64)
+	if err != nil || count <= 0 {
+		return fmt.Errorf("invalid rate_limit count: %q", countStr)
+	}
+	interval, err := caddy.ParseDuration(durationStr)
+	if err != nil || interval <= 0 {
+		return fmt.Errorf("invalid rate_limit duration: %q", durationStr)
+	}
+	m.rateLimitCapacity = count
+	m.rateLimitInterval = interval
+	m.rateTokens.Store(count)
+	m.rateLastRefill.Store(time.Now().UnixNano())
+	return nil
+}
+
+// allowRate reports whether the token bucket has a token to spend for the
+// current request, refilling it first if the interval has elapsed.
+func (m *PlaceholderDump) allowRate() bool {
+	if m.rateLimitCapacity <= 0 {
+		return true
+	}
+
+	now := time.Now().UnixNano()
+	if last := m.rateLastRefill.Load(); now-last >= int64(m.rateLimitInterval) {
+		if m.rateLastRefill.CompareAndSwap(last, now) {
+			m.rateTokens.Store(m.rateLimitCapacity)
+		}
+	}
+
+	for {
+		tokens := m.rateTokens.Load()
+		if tokens <= 0 {
+			return false
+		}
+		if m.rateTokens.CompareAndSwap(tokens, tokens-1) {
+			return true
+		}
+	}
+}
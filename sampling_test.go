@@ -0,0 +1,96 @@
+// Copyright 2025 Steffen Busch
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placeholderdump
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShouldSample(t *testing.T) {
+	tests := []struct {
+		name    string
+		m       *PlaceholderDump
+		repeats int
+		want    int
+	}{
+		{
+			name:    "no sampling configured dumps every request",
+			m:       &PlaceholderDump{},
+			repeats: 5,
+			want:    5,
+		},
+		{
+			name:    "1/3 ratio dumps every third request",
+			m:       &PlaceholderDump{sampleMode: sampleModeRatio, sampleDenom: 3},
+			repeats: 9,
+			want:    3,
+		},
+		{
+			name:    "1/1 ratio dumps every request",
+			m:       &PlaceholderDump{sampleMode: sampleModeRatio, sampleDenom: 1},
+			repeats: 4,
+			want:    4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got int
+			for i := 0; i < tt.repeats; i++ {
+				if tt.m.shouldSample(nil) {
+					got++
+				}
+			}
+			if got != tt.want {
+				t.Fatalf("expected %d of %d requests sampled, got %d", tt.want, tt.repeats, got)
+			}
+		})
+	}
+}
+
+func TestAllowRate(t *testing.T) {
+	t.Run("unlimited when no rate limit is configured", func(t *testing.T) {
+		m := &PlaceholderDump{}
+		for i := 0; i < 3; i++ {
+			if !m.allowRate() {
+				t.Fatal("expected every request to be allowed")
+			}
+		}
+	})
+
+	t.Run("denies once the bucket is exhausted", func(t *testing.T) {
+		m := &PlaceholderDump{rateLimitCapacity: 2, rateLimitInterval: time.Hour}
+		m.rateTokens.Store(2)
+		m.rateLastRefill.Store(time.Now().UnixNano())
+
+		if !m.allowRate() || !m.allowRate() {
+			t.Fatal("expected the first two requests within capacity to be allowed")
+		}
+		if m.allowRate() {
+			t.Fatal("expected the third request to be denied once the bucket is empty")
+		}
+	})
+
+	t.Run("refills once the interval has elapsed", func(t *testing.T) {
+		m := &PlaceholderDump{rateLimitCapacity: 1, rateLimitInterval: time.Nanosecond}
+		m.rateTokens.Store(0)
+		m.rateLastRefill.Store(time.Now().Add(-time.Second).UnixNano())
+
+		if !m.allowRate() {
+			t.Fatal("expected the bucket to refill once the interval has elapsed")
+		}
+	})
+}
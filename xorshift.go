@@ -0,0 +1,47 @@
+// Copyright 2025 Steffen Busch
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// 	http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placeholderdump
+
+import "sync"
+
+// xorshift64 is a minimal xorshift64 PRNG used for fractional sampling
+// decisions. It's not cryptographically secure, but it's fast and good
+// enough for a "dump roughly X% of requests" check on the hot path.
+type xorshift64 struct {
+	mu    sync.Mutex
+	state uint64
+}
+
+// newXorshift64 creates a generator seeded with a non-zero seed.
+func newXorshift64(seed uint64) *xorshift64 {
+	return &xorshift64{state: seed}
+}
+
+// next returns the next pseudo-random uint64 in the sequence.
+func (x *xorshift64) next() uint64 {
+	x.state ^= x.state << 13
+	x.state ^= x.state >> 7
+	x.state ^= x.state << 17
+	return x.state
+}
+
+// lockedFloat64 returns a pseudo-random value in [0, 1), safe for
+// concurrent use across requests.
+func (x *xorshift64) lockedFloat64() float64 {
+	x.mu.Lock()
+	v := x.next()
+	x.mu.Unlock()
+	return float64(v>>11) / (1 << 53)
+}